@@ -0,0 +1,39 @@
+// Copyright 2015 Keybase, Inc. All rights reserved. Use of
+// this source code is governed by the included BSD license.
+
+package engine
+
+import (
+	"golang.org/x/net/context"
+
+	"github.com/keybase/client/go/libkb"
+)
+
+// Context carries the UIs and call-scoped network context an engine needs
+// while it runs. Callers only need to set the UIs an engine's RequiredUIs
+// actually declares; the rest are left as their zero value.
+type Context struct {
+	LogUI            libkb.LogUI
+	SecretUI         libkb.SecretUI
+	CryptocurrencyUI libkb.CryptocurrencyUI
+	NetContext       context.Context
+}
+
+// GetNetContext returns the call-scoped net context, falling back to
+// context.Background() for callers that didn't set one.
+func (c *Context) GetNetContext() context.Context {
+	if c.NetContext != nil {
+		return c.NetContext
+	}
+	return context.Background()
+}
+
+// SecretKeyPromptArg bundles ska with this Context's SecretUI and reason so
+// GetSecretKeyWithPrompt can unlock the key interactively if needed.
+func (c *Context) SecretKeyPromptArg(ska libkb.SecretKeyArg, reason string) libkb.SecretKeyPromptArg {
+	return libkb.SecretKeyPromptArg{
+		Ska:      ska,
+		Reason:   reason,
+		SecretUI: c.SecretUI,
+	}
+}
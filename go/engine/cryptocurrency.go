@@ -4,6 +4,7 @@
 package engine
 
 import (
+	"encoding/hex"
 	"errors"
 	"fmt"
 
@@ -35,10 +36,14 @@ func (e *CryptocurrencyEngine) Prereqs() Prereqs {
 }
 
 func (e *CryptocurrencyEngine) RequiredUIs() []libkb.UIKind {
-	return []libkb.UIKind{
+	uis := []libkb.UIKind{
 		libkb.LogUIKind,
 		libkb.SecretUIKind,
 	}
+	if e.arg.ProveControl {
+		uis = append(uis, libkb.CryptocurrencyUIKind)
+	}
+	return uis
 }
 
 func (e *CryptocurrencyEngine) SubConsumers() []libkb.UIConsumer {
@@ -51,14 +56,33 @@ func (e *CryptocurrencyEngine) Run(ctx *Context) (err error) {
 
 	defer e.G().Trace("CryptocurrencyEngine", func() error { return err })()
 
+	if e.arg.WantedFamily == string(libkb.CryptocurrencyFamilyFilecoin) && e.arg.MultisigPolicy != nil {
+		return libkb.InvalidAddressError{Msg: "cannot request both a filecoin address and a multisig policy"}
+	}
+
 	var typ libkb.CryptocurrencyType
-	typ, _, err = libkb.CryptocurrencyParseAndCheck(e.arg.Address)
+	var family libkb.CryptocurrencyFamily
+	switch {
+	case e.arg.WantedFamily == string(libkb.CryptocurrencyFamilyFilecoin):
+		typ, _, err = libkb.ParseFilecoinAddress(e.arg.Address)
+		family = libkb.CryptocurrencyFamilyFilecoin
+	case e.arg.MultisigPolicy != nil:
+		policy := libkb.MultisigPolicy{
+			Required:        e.arg.MultisigPolicy.Required,
+			CoSigners:       e.arg.MultisigPolicy.CoSigners,
+			ContractAddress: e.arg.MultisigPolicy.ContractAddress,
+		}
+		typ, err = libkb.CryptocurrencyParseAndCheckMultisig(e.arg.Address, policy)
+		family = typ.ToCryptocurrencyFamily()
+	default:
+		typ, _, err = libkb.CryptocurrencyParseAndCheck(e.arg.Address)
+		family = typ.ToCryptocurrencyFamily()
+	}
 
 	if err != nil {
 		return libkb.InvalidAddressError{Msg: err.Error()}
 	}
 
-	family := typ.ToCryptocurrencyFamily()
 	if len(e.arg.WantedFamily) > 0 && e.arg.WantedFamily != string(family) {
 		return libkb.InvalidAddressError{Msg: fmt.Sprintf("wanted coin type %q, but got %q", e.arg.WantedFamily, family)}
 	}
@@ -151,6 +175,15 @@ func (e *CryptocurrencyEngine) Run(ctx *Context) (err error) {
 		args["sig_inner"] = libkb.S{Val: string(sigInner)}
 	}
 
+	if e.arg.ProveControl {
+		controlSig, controlSigKind, err := e.proveControl(ctx, me.GetUID(), typ)
+		if err != nil {
+			return err
+		}
+		args["control_sig"] = libkb.S{Val: hex.EncodeToString(controlSig)}
+		args["control_sig_kind"] = libkb.S{Val: string(controlSigKind)}
+	}
+
 	_, err = e.G().API.Post(libkb.APIArg{
 		Endpoint:    "sig/post",
 		SessionType: libkb.APISessionTypeREQUIRED,
@@ -166,6 +199,46 @@ func (e *CryptocurrencyEngine) Run(ctx *Context) (err error) {
 	return nil
 }
 
+// proveControl asks the server for a challenge nonce and obtains a
+// signature over it from the address's private key, proving the caller
+// holds that key rather than just asserting ownership. If
+// RegisterAddressArg.SignerURI is set, the signature comes from the
+// external signer it names (a KMS, HSM, or agent process) instead of
+// prompting the interactive user; the engine never sees key material
+// either way.
+func (e *CryptocurrencyEngine) proveControl(ctx *Context, uid keybase1.UID, typ libkb.CryptocurrencyType) ([]byte, libkb.SignatureKind, error) {
+	kind, err := libkb.SignatureKindForCryptocurrencyType(typ)
+	if err != nil {
+		return nil, "", err
+	}
+	nonce, err := libkb.RequestControlChallengeNonce(ctx.GetNetContext(), e.G(), uid)
+	if err != nil {
+		return nil, "", err
+	}
+	digest := libkb.ControlChallengeDigest(uid, nonce)
+
+	if e.arg.SignerURI != "" {
+		signer, err := libkb.ResolveExternalChainSigner(e.arg.SignerURI)
+		if err != nil {
+			return nil, "", err
+		}
+		sig, signerKind, err := signer.Sign(ctx.GetNetContext(), e.arg.Address, digest)
+		if err != nil {
+			return nil, "", err
+		}
+		if signerKind != kind {
+			return nil, "", fmt.Errorf("external signer at %q returned a %s signature, but address %s requires %s", e.arg.SignerURI, signerKind, e.arg.Address, kind)
+		}
+		return sig, signerKind, nil
+	}
+
+	sig, err := ctx.CryptocurrencyUI.PromptControlSignature(ctx.GetNetContext(), e.arg.Address, kind, digest)
+	if err != nil {
+		return nil, "", err
+	}
+	return sig, kind, nil
+}
+
 func (e *CryptocurrencyEngine) Result() keybase1.RegisterAddressRes {
 	return e.res
 }
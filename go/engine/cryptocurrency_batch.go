@@ -0,0 +1,172 @@
+// Copyright 2021 Keybase, Inc. All rights reserved. Use of
+// this source code is governed by the included BSD license.
+
+package engine
+
+import (
+	"errors"
+
+	"github.com/keybase/client/go/libkb"
+	keybase1 "github.com/keybase/client/go/protocol/keybase1"
+)
+
+// RegisterAddressesEngine registers several cryptocurrency addresses in a
+// single sigchain V2 link, rather than running CryptocurrencyEngine once
+// per address. This keeps the sigchain shorter for users who publish many
+// coins, and lets the identify UI's coin section update atomically instead
+// of flickering through partial states.
+type RegisterAddressesEngine struct {
+	libkb.Contextified
+	arg keybase1.RegisterAddressesArg
+	res keybase1.RegisterAddressesRes
+}
+
+func NewRegisterAddressesEngine(g *libkb.GlobalContext, arg keybase1.RegisterAddressesArg) *RegisterAddressesEngine {
+	return &RegisterAddressesEngine{
+		Contextified: libkb.NewContextified(g),
+		arg:          arg,
+	}
+}
+
+func (e *RegisterAddressesEngine) Name() string {
+	return "RegisterAddresses"
+}
+
+func (e *RegisterAddressesEngine) Prereqs() Prereqs {
+	return Prereqs{
+		Device: true,
+	}
+}
+
+func (e *RegisterAddressesEngine) RequiredUIs() []libkb.UIKind {
+	return []libkb.UIKind{
+		libkb.LogUIKind,
+		libkb.SecretUIKind,
+	}
+}
+
+func (e *RegisterAddressesEngine) SubConsumers() []libkb.UIConsumer {
+	return []libkb.UIConsumer{}
+}
+
+func (e *RegisterAddressesEngine) Run(ctx *Context) (err error) {
+	e.G().LocalSigchainGuard().Set(ctx.GetNetContext(), "RegisterAddressesEngine")
+	defer e.G().LocalSigchainGuard().Clear(ctx.GetNetContext(), "RegisterAddressesEngine")
+
+	defer e.G().Trace("RegisterAddressesEngine", func() error { return err })()
+
+	if len(e.arg.Addresses) == 0 {
+		return errors.New("no addresses given to register")
+	}
+
+	me, err := libkb.LoadMe(libkb.NewLoadUserArg(e.G()))
+	if err != nil {
+		return err
+	}
+
+	entries := make([]libkb.CryptocurrencyBatchEntry, len(e.arg.Addresses))
+	for i, addr := range e.arg.Addresses {
+		typ, _, err := libkb.CryptocurrencyParseAndCheck(addr)
+		if err != nil {
+			return libkb.InvalidAddressError{Msg: err.Error()}
+		}
+		entries[i] = libkb.CryptocurrencyBatchEntry{Address: addr, Family: typ.ToCryptocurrencyFamily(), Type: typ}
+	}
+	if err := libkb.ValidateBatchEntries(entries); err != nil {
+		return err
+	}
+
+	var sigIDsToRevoke []keybase1.SigID
+	for _, entry := range entries {
+		if link := me.IDTable().ActiveCryptocurrency(entry.Family); link != nil {
+			if !e.arg.Force {
+				return libkb.ExistsError{Msg: string(entry.Family)}
+			}
+			sigIDsToRevoke = append(sigIDsToRevoke, link.GetSigID())
+		}
+	}
+
+	var lease *libkb.Lease
+	var merkleRoot *libkb.MerkleRoot
+	if len(sigIDsToRevoke) > 0 {
+		lease, merkleRoot, err = libkb.RequestDowngradeLeaseBySigIDs(ctx.NetContext, e.G(), sigIDsToRevoke)
+		if err != nil {
+			return err
+		}
+	}
+
+	ska := libkb.SecretKeyArg{
+		Me:      me,
+		KeyType: libkb.DeviceSigningKeyType,
+	}
+	sigKey, err := e.G().Keyrings.GetSecretKeyWithPrompt(ctx.SecretKeyPromptArg(ska, "to register cryptocurrency addresses"))
+	if err != nil {
+		return err
+	}
+	if err = sigKey.CheckSecretKey(); err != nil {
+		return err
+	}
+
+	sigVersion := libkb.SigVersion(e.arg.SigVersion)
+	if sigVersion == 0 {
+		sigVersion = libkb.KeybaseSignatureV2
+	}
+	if sigVersion != libkb.KeybaseSignatureV2 {
+		return errors.New("batch cryptocurrency registration requires sigchain V2")
+	}
+
+	claim, err := me.CryptocurrencyBatchSig(sigKey, entries, sigIDsToRevoke, merkleRoot, sigVersion)
+	if err != nil {
+		return err
+	}
+
+	sigInner, err := claim.Marshal()
+	if err != nil {
+		return err
+	}
+
+	prevSeqno := me.GetSigChainLastKnownSeqno()
+	prevLinkID := me.GetSigChainLastKnownID()
+	sig, _, _, err := libkb.MakeSigchainV2OuterSig(
+		sigKey,
+		libkb.LinkTypeCryptocurrencyBatch,
+		prevSeqno+1,
+		sigInner,
+		prevLinkID,
+		len(sigIDsToRevoke) > 0, /* hasRevokes */
+		keybase1.SeqType_PUBLIC,
+		false, /* ignoreIfUnsupported */
+	)
+	if err != nil {
+		return err
+	}
+
+	kid := sigKey.GetKID()
+	args := libkb.HTTPArgs{
+		"sig":             libkb.S{Val: sig},
+		"signing_kid":     libkb.S{Val: kid.String()},
+		"is_remote_proof": libkb.B{Val: false},
+		"type":            libkb.S{Val: "cryptocurrency_batch"},
+		"sig_inner":       libkb.S{Val: string(sigInner)},
+	}
+	if lease != nil {
+		args["downgrade_lease_id"] = libkb.S{Val: string(lease.LeaseID)}
+	}
+
+	_, err = e.G().API.Post(libkb.APIArg{
+		Endpoint:    "sig/post",
+		SessionType: libkb.APISessionTypeREQUIRED,
+		Args:        args,
+	})
+	if err != nil {
+		return err
+	}
+
+	e.res.Addresses = e.arg.Addresses
+
+	return nil
+}
+
+func (e *RegisterAddressesEngine) Result() keybase1.RegisterAddressesRes {
+	return e.res
+}
@@ -0,0 +1,72 @@
+// Copyright 2020 Keybase, Inc. All rights reserved. Use of
+// this source code is governed by the included BSD license.
+
+// Package control verifies proof-of-control signatures produced by
+// CryptocurrencyEngine's ProveControl flow: a signature, under the
+// cryptocurrency's own curve, over a server-issued challenge. It's kept
+// separate from libkb so identify code can check a "verified control"
+// claim without pulling in the full registration engine.
+package control
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/keybase/client/go/libkb"
+)
+
+// Verify checks that sig is a valid proof-of-control signature over digest
+// under kind, for the key or address expectedKey claims. It returns
+// (false, nil) for a syntactically valid but non-matching signature, and a
+// non-nil error only when verification itself couldn't be attempted.
+func Verify(kind libkb.SignatureKind, expectedKey []byte, digest []byte, sig []byte) (bool, error) {
+	switch kind {
+	case libkb.SignatureKindEd25519:
+		return verifyEd25519(expectedKey, digest, sig)
+	case libkb.SignatureKindSecp256k1:
+		return verifySecp256k1(expectedKey, digest, sig)
+	case libkb.SignatureKindBLS12381:
+		return verifyBLS12381(expectedKey, digest, sig)
+	default:
+		return false, errors.New("control: unknown signature kind")
+	}
+}
+
+func verifyEd25519(expectedKey, digest, sig []byte) (bool, error) {
+	if len(expectedKey) != ed25519.PublicKeySize {
+		return false, errors.New("control: bad ed25519 public key length")
+	}
+	return ed25519.Verify(ed25519.PublicKey(expectedKey), digest, sig), nil
+}
+
+// verifySecp256k1 recovers the public key from a 65-byte recoverable
+// signature (as produced by Bitcoin/Ethereum wallets) and checks it
+// against expectedKey, which may be either the raw compressed public key
+// or a HASH160 of it (a P2PKH-style address payload).
+func verifySecp256k1(expectedKey, digest, sig []byte) (bool, error) {
+	if len(sig) != 65 {
+		return false, errors.New("control: secp256k1 recoverable signature must be 65 bytes")
+	}
+	pubKey, _, err := btcec.RecoverCompact(btcec.S256(), sig, digest)
+	if err != nil {
+		// A signature that fails to recover a key at all is not verifiable,
+		// but that's a "no" on control, not an error worth surfacing.
+		return false, nil
+	}
+	compressed := pubKey.SerializeCompressed()
+	if len(expectedKey) == len(compressed) {
+		return bytes.Equal(compressed, expectedKey), nil
+	}
+	return bytes.Equal(libkb.Hash160(compressed), expectedKey), nil
+}
+
+// verifyBLS12381 would check a Filecoin f3-style BLS signature, but this
+// tree doesn't vendor a BLS12-381 pairing library, so there's nothing to
+// check against yet. SignatureKindForCryptocurrencyType never returns this
+// kind for that reason -- this stays in place only so Verify has a branch
+// to extend once a pairing library is vendored.
+func verifyBLS12381(expectedKey, digest, sig []byte) (bool, error) {
+	return false, errors.New("control: bls12-381 verification requires vendoring a pairing library")
+}
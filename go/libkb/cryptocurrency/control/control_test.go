@@ -0,0 +1,124 @@
+// Copyright 2020 Keybase, Inc. All rights reserved. Use of
+// this source code is governed by the included BSD license.
+
+package control
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/keybase/client/go/libkb"
+	keybase1 "github.com/keybase/client/go/protocol/keybase1"
+)
+
+func TestVerifyEd25519(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	digest := sha256.Sum256([]byte("challenge"))
+	sig := ed25519.Sign(priv, digest[:])
+
+	ok, err := Verify(libkb.SignatureKindEd25519, pub, digest[:], sig)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !ok {
+		t.Errorf("expected a valid ed25519 signature to verify")
+	}
+
+	otherDigest := sha256.Sum256([]byte("different challenge"))
+	ok, err = Verify(libkb.SignatureKindEd25519, pub, otherDigest[:], sig)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if ok {
+		t.Errorf("expected a signature over a different digest to fail verification")
+	}
+}
+
+func TestVerifySecp256k1(t *testing.T) {
+	key, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	digest := sha256.Sum256([]byte("challenge"))
+	sig, err := btcec.SignCompact(btcec.S256(), key, digest[:], true)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	compressed := key.PubKey().SerializeCompressed()
+	ok, err := Verify(libkb.SignatureKindSecp256k1, compressed, digest[:], sig)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !ok {
+		t.Errorf("expected a valid secp256k1 signature to verify against the raw public key")
+	}
+
+	ok, err = Verify(libkb.SignatureKindSecp256k1, libkb.Hash160(compressed), digest[:], sig)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !ok {
+		t.Errorf("expected a valid secp256k1 signature to verify against a HASH160 address payload")
+	}
+}
+
+// TestVerifySecp256k1WithControlChallengeDigest exercises the real
+// production digest (libkb.ControlChallengeDigest), not a hand-built
+// sha256.Sum256 fixture, to catch truncation bugs in how it's built.
+func TestVerifySecp256k1WithControlChallengeDigest(t *testing.T) {
+	key, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	uid := keybase1.UID("295a7eea607879d1f4770b0001119a00")
+	nonce := []byte("0123456789abcdef0123456789abcdef")
+	digest := libkb.ControlChallengeDigest(uid, nonce)
+	if len(digest) != 32 {
+		t.Fatalf("expected a 32-byte digest, got %d bytes", len(digest))
+	}
+
+	sig, err := btcec.SignCompact(btcec.S256(), key, digest, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	compressed := key.PubKey().SerializeCompressed()
+	ok, err := Verify(libkb.SignatureKindSecp256k1, compressed, digest, sig)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !ok {
+		t.Errorf("expected a signature over the real control-challenge digest to verify")
+	}
+
+	// A signature over a stale nonce must not verify against a fresh one --
+	// this is the actual replay protection the nonce exists for.
+	otherDigest := libkb.ControlChallengeDigest(uid, []byte("fedcba9876543210fedcba9876543210"))
+	ok, err = Verify(libkb.SignatureKindSecp256k1, compressed, otherDigest, sig)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if ok {
+		t.Errorf("expected a signature over a stale nonce to fail verification against a fresh one")
+	}
+}
+
+func TestVerifyBLS12381Unsupported(t *testing.T) {
+	if _, err := Verify(libkb.SignatureKindBLS12381, nil, nil, nil); err == nil {
+		t.Errorf("expected bls12-381 verification to report it is unsupported")
+	}
+}
+
+func TestVerifyUnknownKind(t *testing.T) {
+	if _, err := Verify(libkb.SignatureKind("made-up"), nil, nil, nil); err == nil {
+		t.Errorf("expected an unknown signature kind to error")
+	}
+}
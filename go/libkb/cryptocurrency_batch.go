@@ -0,0 +1,87 @@
+// Copyright 2021 Keybase, Inc. All rights reserved. Use of
+// this source code is governed by the included BSD license.
+
+package libkb
+
+import (
+	"fmt"
+
+	keybase1 "github.com/keybase/client/go/protocol/keybase1"
+	jsonw "github.com/keybase/go-jsonw"
+)
+
+// LinkTypeCryptocurrencyBatch is the sigchain V2 link type for a
+// CryptocurrencyBatchSig: several address registrations committed in a
+// single link, rather than one link per address.
+const LinkTypeCryptocurrencyBatch LinkType = "cryptocurrency_batch"
+
+// CryptocurrencyBatchEntry is one address within a CryptocurrencyBatchSig.
+type CryptocurrencyBatchEntry struct {
+	Address string
+	Family  CryptocurrencyFamily
+	Type    CryptocurrencyType
+}
+
+// ValidateBatchEntries checks that entries names at most one address per
+// coin family, since a batch link can only assert (and atomically revoke)
+// a single active address per family, same as the non-batch engine does
+// one family at a time.
+func ValidateBatchEntries(entries []CryptocurrencyBatchEntry) error {
+	seenFamily := make(map[CryptocurrencyFamily]bool, len(entries))
+	for _, entry := range entries {
+		if seenFamily[entry.Family] {
+			return fmt.Errorf("more than one address given for family %q", entry.Family)
+		}
+		seenFamily[entry.Family] = true
+	}
+	return nil
+}
+
+// populateCryptocurrencyBatchBody fills in a sigchain link body dictionary
+// with entries' addresses, any sig IDs they revoke, and the merkle root
+// pinning the link. It's kept separate from CryptocurrencyBatchSig so the
+// JSON shape it produces can be unit tested without a live User.
+func populateCryptocurrencyBatchBody(body *jsonw.Wrapper, entries []CryptocurrencyBatchEntry, sigIDsToRevoke []keybase1.SigID, merkleRoot *MerkleRoot) {
+	currencyArray := jsonw.NewArray(len(entries))
+	for i, entry := range entries {
+		currencyEntry := jsonw.NewDictionary()
+		currencyEntry.SetKey("address", jsonw.NewString(entry.Address))
+		currencyEntry.SetKey("family", jsonw.NewString(string(entry.Family)))
+		currencyEntry.SetKey("type", jsonw.NewString(entry.Type.String()))
+		currencyArray.SetIndex(i, currencyEntry)
+	}
+	body.SetKey("cryptocurrencies", currencyArray)
+
+	if len(sigIDsToRevoke) > 0 {
+		revokeArray := jsonw.NewArray(len(sigIDsToRevoke))
+		for i, sigID := range sigIDsToRevoke {
+			revokeArray.SetIndex(i, jsonw.NewString(sigID.String()))
+		}
+		revoke := jsonw.NewDictionary()
+		revoke.SetKey("sig_ids", revokeArray)
+		body.SetKey("revoke", revoke)
+	}
+
+	if merkleRoot != nil {
+		body.SetKey("merkle_root", merkleRoot.ToSigJSON())
+	}
+}
+
+// CryptocurrencyBatchSig is the batch counterpart to User.CryptocurrencySig:
+// it builds a single sigchain link body asserting ownership of several
+// cryptocurrency addresses at once, each of which may revoke a prior
+// address in the same family.
+func (u *User) CryptocurrencyBatchSig(signingKey GenericKey, entries []CryptocurrencyBatchEntry, sigIDsToRevoke []keybase1.SigID, merkleRoot *MerkleRoot, sigVersion SigVersion) (*jsonw.Wrapper, error) {
+	if err := ValidateBatchEntries(entries); err != nil {
+		return nil, err
+	}
+
+	ret, err := u.ProofMetadata(signingKey, LinkTypeCryptocurrencyBatch, sigVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	populateCryptocurrencyBatchBody(ret.AtKey("body"), entries, sigIDsToRevoke, merkleRoot)
+
+	return ret, nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2021 Keybase, Inc. All rights reserved. Use of
+// this source code is governed by the included BSD license.
+
+package libkb
+
+import (
+	"encoding/json"
+	"testing"
+
+	keybase1 "github.com/keybase/client/go/protocol/keybase1"
+	jsonw "github.com/keybase/go-jsonw"
+)
+
+func TestValidateBatchEntriesDistinctFamilies(t *testing.T) {
+	entries := []CryptocurrencyBatchEntry{
+		{Address: "1abc", Family: CryptocurrencyFamilyBitcoin, Type: CryptocurrencyTypeBTC},
+		{Address: "0xabc", Family: CryptocurrencyFamilyEthereum, Type: CryptocurrencyTypeETH},
+	}
+	if err := ValidateBatchEntries(entries); err != nil {
+		t.Errorf("unexpected error for distinct families: %s", err)
+	}
+}
+
+func TestValidateBatchEntriesDuplicateFamily(t *testing.T) {
+	entries := []CryptocurrencyBatchEntry{
+		{Address: "1abc", Family: CryptocurrencyFamilyBitcoin, Type: CryptocurrencyTypeBTC},
+		{Address: "1def", Family: CryptocurrencyFamilyBitcoin, Type: CryptocurrencyTypeBTC},
+	}
+	if err := ValidateBatchEntries(entries); err == nil {
+		t.Errorf("expected an error for two addresses in the same family")
+	}
+}
+
+func TestPopulateCryptocurrencyBatchBody(t *testing.T) {
+	entries := []CryptocurrencyBatchEntry{
+		{Address: "1abc", Family: CryptocurrencyFamilyBitcoin, Type: CryptocurrencyTypeBTC},
+		{Address: "0xabc", Family: CryptocurrencyFamilyEthereum, Type: CryptocurrencyTypeETH},
+	}
+	sigIDsToRevoke := []keybase1.SigID{keybase1.SigID("0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcd0f")}
+
+	body := jsonw.NewDictionary()
+	populateCryptocurrencyBatchBody(body, entries, sigIDsToRevoke, nil)
+
+	raw, err := body.Marshal()
+	if err != nil {
+		t.Fatalf("unexpected error marshaling body: %s", err)
+	}
+
+	var parsed struct {
+		Cryptocurrencies []struct {
+			Address string `json:"address"`
+			Family  string `json:"family"`
+			Type    string `json:"type"`
+		} `json:"cryptocurrencies"`
+		Revoke struct {
+			SigIDs []string `json:"sig_ids"`
+		} `json:"revoke"`
+	}
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		t.Fatalf("unexpected error unmarshaling body: %s", err)
+	}
+
+	if len(parsed.Cryptocurrencies) != len(entries) {
+		t.Fatalf("expected %d cryptocurrencies, got %d", len(entries), len(parsed.Cryptocurrencies))
+	}
+	for i, entry := range entries {
+		if parsed.Cryptocurrencies[i].Address != entry.Address {
+			t.Errorf("entry %d: expected address %q, got %q", i, entry.Address, parsed.Cryptocurrencies[i].Address)
+		}
+		if parsed.Cryptocurrencies[i].Family != string(entry.Family) {
+			t.Errorf("entry %d: expected family %q, got %q", i, entry.Family, parsed.Cryptocurrencies[i].Family)
+		}
+		if parsed.Cryptocurrencies[i].Type != entry.Type.String() {
+			t.Errorf("entry %d: expected type %q, got %q", i, entry.Type.String(), parsed.Cryptocurrencies[i].Type)
+		}
+	}
+
+	if len(parsed.Revoke.SigIDs) != len(sigIDsToRevoke) {
+		t.Fatalf("expected %d revoked sig IDs, got %d", len(sigIDsToRevoke), len(parsed.Revoke.SigIDs))
+	}
+	if parsed.Revoke.SigIDs[0] != sigIDsToRevoke[0].String() {
+		t.Errorf("expected revoked sig ID %q, got %q", sigIDsToRevoke[0].String(), parsed.Revoke.SigIDs[0])
+	}
+}
+
+func TestPopulateCryptocurrencyBatchBodyNoRevokes(t *testing.T) {
+	entries := []CryptocurrencyBatchEntry{
+		{Address: "1abc", Family: CryptocurrencyFamilyBitcoin, Type: CryptocurrencyTypeBTC},
+	}
+
+	body := jsonw.NewDictionary()
+	populateCryptocurrencyBatchBody(body, entries, nil, nil)
+
+	raw, err := body.Marshal()
+	if err != nil {
+		t.Fatalf("unexpected error marshaling body: %s", err)
+	}
+
+	var parsed map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		t.Fatalf("unexpected error unmarshaling body: %s", err)
+	}
+	if _, ok := parsed["revoke"]; ok {
+		t.Errorf("expected no revoke key when no sig IDs are being revoked")
+	}
+}
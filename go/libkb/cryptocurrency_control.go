@@ -0,0 +1,104 @@
+// Copyright 2020 Keybase, Inc. All rights reserved. Use of
+// this source code is governed by the included BSD license.
+
+package libkb
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"golang.org/x/net/context"
+
+	keybase1 "github.com/keybase/client/go/protocol/keybase1"
+)
+
+// SignatureKind identifies the curve/scheme an external-chain signature was
+// produced with, so verifiers (and the `identify` UI) know how to check it
+// against the address a ProveControl registration claims to own.
+type SignatureKind string
+
+const (
+	SignatureKindSecp256k1 SignatureKind = "secp256k1" // Bitcoin, Ethereum (recoverable)
+	SignatureKindEd25519   SignatureKind = "ed25519"   // Stellar, Zcash Sapling
+	SignatureKindBLS12381  SignatureKind = "bls12-381" // Filecoin f3
+)
+
+// SignatureKindForCryptocurrencyType picks the signature scheme that proves
+// control of an address of the given type. CryptocurrencyTypeFilecoinBLS is
+// deliberately absent: this tree doesn't vendor a BLS12-381 pairing
+// library, so control.Verify can't check a BLS signature yet, and we'd
+// rather reject ProveControl up front than accept a signature nothing can
+// verify.
+func SignatureKindForCryptocurrencyType(typ CryptocurrencyType) (SignatureKind, error) {
+	switch typ {
+	case CryptocurrencyTypeBTC, CryptocurrencyTypeBTCSegwit, CryptocurrencyTypeETH:
+		return SignatureKindSecp256k1, nil
+	case CryptocurrencyTypeXLM, CryptocurrencyTypeZEC:
+		return SignatureKindEd25519, nil
+	case CryptocurrencyTypeFilecoinSECP256K1:
+		return SignatureKindSecp256k1, nil
+	default:
+		return "", UnknownCryptocurrencyTypeError{Typ: typ}
+	}
+}
+
+// UnknownCryptocurrencyTypeError is returned when no signature scheme is
+// known for a given CryptocurrencyType, e.g. because ProveControl isn't
+// supported yet for that coin.
+type UnknownCryptocurrencyTypeError struct {
+	Typ CryptocurrencyType
+}
+
+func (e UnknownCryptocurrencyTypeError) Error() string {
+	return "no proof-of-control signature scheme is known for this cryptocurrency type"
+}
+
+// CryptocurrencyUIKind identifies CryptocurrencyUI in RequiredUIs/UI
+// routing, alongside the existing LogUIKind and SecretUIKind.
+const CryptocurrencyUIKind UIKind = "cryptocurrencyUi"
+
+// CryptocurrencyUI prompts the user to sign a server-issued challenge with
+// the private key for the address they're registering, as part of the
+// optional ProveControl flow on CryptocurrencyEngine. Implementations may
+// prompt interactively, or simply delegate to an ExternalChainSigner.
+type CryptocurrencyUI interface {
+	PromptControlSignature(ctx context.Context, address string, kind SignatureKind, challenge []byte) ([]byte, error)
+}
+
+// RequestControlChallengeNonce asks the server for a fresh nonce to be
+// signed as part of a ProveControl registration. The nonce is combined
+// with the caller's UID before signing so a signature can't be replayed
+// against a different Keybase user.
+func RequestControlChallengeNonce(ctx context.Context, g *GlobalContext, uid keybase1.UID) ([]byte, error) {
+	res, err := g.API.Get(APIArg{
+		Endpoint:    "sig/control_challenge",
+		SessionType: APISessionTypeREQUIRED,
+		Args: HTTPArgs{
+			"uid": S{Val: uid.String()},
+		},
+		NetContext: ctx,
+	})
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := res.Body.AtKey("nonce").GetString()
+	if err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(nonce)
+}
+
+// ControlChallengeDigest is the message a ProveControl signature must cover:
+// the sha256 of the UID proving the address followed by the server-issued
+// nonce, so a signature can't be lifted and replayed for a different
+// account. It's hashed down to a fixed 32 bytes rather than signed raw,
+// since secp256k1 signing (btcec's hashToInt) silently truncates any input
+// longer than the curve-order byte length -- a raw concatenation would let
+// the trailing nonce bytes fall outside what's actually signed.
+func ControlChallengeDigest(uid keybase1.UID, nonce []byte) []byte {
+	preimage := make([]byte, 0, len(uid)+len(nonce))
+	preimage = append(preimage, []byte(uid)...)
+	preimage = append(preimage, nonce...)
+	digest := sha256.Sum256(preimage)
+	return digest[:]
+}
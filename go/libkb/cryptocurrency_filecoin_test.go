@@ -0,0 +1,94 @@
+// Copyright 2019 Keybase, Inc. All rights reserved. Use of
+// this source code is governed by the included BSD license.
+
+package libkb
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// makeFilecoinAddress builds a syntactically valid fX address with a
+// correct blake2b-4 checksum over the given payload, for use as test
+// fixtures.
+func makeFilecoinAddress(protocol byte, payload []byte) string {
+	sum, err := blake2b.New(4, nil)
+	if err != nil {
+		panic(err)
+	}
+	sum.Write([]byte{protocol})
+	sum.Write(payload)
+	raw := append(append([]byte{}, payload...), sum.Sum(nil)...)
+	encoded := strings.ToLower(filecoinBase32.EncodeToString(raw))
+	return "f" + string(protocol) + encoded
+}
+
+func TestParseFilecoinAddressSECP256K1(t *testing.T) {
+	payload := make([]byte, 20)
+	for i := range payload {
+		payload[i] = byte(i)
+	}
+	addr := makeFilecoinAddress('1', payload)
+
+	typ, raw, err := ParseFilecoinAddress(addr)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if typ != CryptocurrencyTypeFilecoinSECP256K1 {
+		t.Errorf("expected secp256k1 type, got %v", typ)
+	}
+	if string(raw) != string(payload) {
+		t.Errorf("decoded payload did not round-trip")
+	}
+}
+
+func TestParseFilecoinAddressBLS(t *testing.T) {
+	payload := make([]byte, 48)
+	for i := range payload {
+		payload[i] = byte(i * 3)
+	}
+	addr := makeFilecoinAddress('3', payload)
+
+	typ, raw, err := ParseFilecoinAddress(addr)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if typ != CryptocurrencyTypeFilecoinBLS {
+		t.Errorf("expected BLS type, got %v", typ)
+	}
+	if string(raw) != string(payload) {
+		t.Errorf("decoded payload did not round-trip")
+	}
+}
+
+func TestParseFilecoinAddressUnprovableProtocols(t *testing.T) {
+	for _, protocol := range []byte{'0', '2'} {
+		addr := makeFilecoinAddress(protocol, []byte{1, 2, 3})
+		if _, _, err := ParseFilecoinAddress(addr); err == nil {
+			t.Errorf("expected protocol %q to be rejected as unprovable", string(protocol))
+		}
+	}
+}
+
+func TestParseFilecoinAddressBadChecksum(t *testing.T) {
+	payload := []byte{1, 2, 3, 4, 5}
+	addr := makeFilecoinAddress('1', payload)
+	// Change the first character of the encoded body, which carries payload
+	// bits, so the decoded payload no longer matches its checksum.
+	corrupted := []byte(addr)
+	corrupted[2]++
+	if _, _, err := ParseFilecoinAddress(string(corrupted)); err == nil {
+		t.Errorf("expected a bad checksum to be rejected")
+	}
+}
+
+func TestParseFilecoinAddressMalformed(t *testing.T) {
+	cases := []string{"", "f", "fx", "bc1qxyz"}
+	for _, addr := range cases {
+		if _, _, err := ParseFilecoinAddress(addr); err == nil {
+			t.Errorf("expected %q to be rejected as malformed", addr)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2018 Keybase, Inc. All rights reserved. Use of
+// this source code is governed by the included BSD license.
+
+package libkb
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"golang.org/x/crypto/ripemd160"
+)
+
+// MultisigPolicy describes an M-of-N multisig cryptocurrency address being
+// registered, rather than an address derived from a single signing key.
+// CoSigners holds the N co-signer public keys, hex-encoded in compressed
+// (33-byte) or uncompressed (65-byte) secp256k1 form, in the same order
+// they appear in the redeem script; Required is the M threshold that must
+// sign to spend. ContractAddress is set instead for Gnosis-style Ethereum
+// multisig wallets, where the "address" is a deployed contract rather than
+// a hash of a script, and CoSigners is left empty.
+type MultisigPolicy struct {
+	Required        int
+	CoSigners       []string
+	ContractAddress string
+}
+
+// Validate checks that the policy is internally consistent: exactly one of
+// CoSigners/ContractAddress is set, and for a script-based policy, a
+// positive threshold that doesn't exceed the number of co-signers provided.
+func (p MultisigPolicy) Validate() error {
+	if p.ContractAddress != "" {
+		if len(p.CoSigners) > 0 {
+			return fmt.Errorf("multisig policy cannot set both ContractAddress and CoSigners")
+		}
+		return nil
+	}
+	if p.Required < 1 || p.Required > 16 {
+		return fmt.Errorf("multisig threshold must be between 1 and 16, got %d", p.Required)
+	}
+	if len(p.CoSigners) == 0 || len(p.CoSigners) > 16 {
+		return fmt.Errorf("multisig policy must have between 1 and 16 co-signers, got %d", len(p.CoSigners))
+	}
+	if p.Required > len(p.CoSigners) {
+		return fmt.Errorf("multisig threshold %d exceeds the %d co-signers provided", p.Required, len(p.CoSigners))
+	}
+	return nil
+}
+
+// opN returns the script opcode pushing the small integer n (1-16), i.e.
+// OP_1 through OP_16.
+func opN(n int) byte {
+	return byte(0x50 + n)
+}
+
+const opCheckMultisig = 0xae
+
+// RedeemScript builds the bare M-of-N script
+//
+//	OP_<Required> <pubkey_1> ... <pubkey_N> OP_<N> OP_CHECKMULTISIG
+//
+// that a P2SH or P2WSH address hashes, in co-signer order. It returns an
+// error if any co-signer isn't a valid hex-encoded secp256k1 public key.
+func (p MultisigPolicy) RedeemScript() ([]byte, error) {
+	var script bytes.Buffer
+	script.WriteByte(opN(p.Required))
+	for _, signer := range p.CoSigners {
+		pubKey, err := hex.DecodeString(signer)
+		if err != nil {
+			return nil, fmt.Errorf("co-signer %q is not a hex-encoded public key: %s", signer, err)
+		}
+		if len(pubKey) != 33 && len(pubKey) != 65 {
+			return nil, fmt.Errorf("co-signer public key %q has invalid length %d", signer, len(pubKey))
+		}
+		script.WriteByte(byte(len(pubKey)))
+		script.Write(pubKey)
+	}
+	script.WriteByte(opN(len(p.CoSigners)))
+	script.WriteByte(opCheckMultisig)
+	return script.Bytes(), nil
+}
+
+// Hash160 is HASH160 as Bitcoin script defines it: RIPEMD160(SHA256(b)).
+// It's exported so the proof-of-control verifier can compare a recovered
+// secp256k1 public key against a P2PKH-style HASH160 address payload.
+func Hash160(b []byte) []byte {
+	shaSum := sha256.Sum256(b)
+	r := ripemd160.New()
+	r.Write(shaSum[:])
+	return r.Sum(nil)
+}
+
+// DeriveMultisigScriptHash hashes RedeemScript the way the claimed address
+// was encoded: HASH160 for a 20-byte P2SH script hash, or plain SHA256 for
+// a 32-byte P2WSH script hash. scriptHashLen is the length of the raw bytes
+// CryptocurrencyParseAndCheck decoded out of the address.
+func (p MultisigPolicy) DeriveMultisigScriptHash(scriptHashLen int) ([]byte, error) {
+	script, err := p.RedeemScript()
+	if err != nil {
+		return nil, err
+	}
+	switch scriptHashLen {
+	case 20:
+		return Hash160(script), nil
+	case 32:
+		sum := sha256.Sum256(script)
+		return sum[:], nil
+	default:
+		return nil, fmt.Errorf("address does not decode to a P2SH (20-byte) or P2WSH (32-byte) script hash")
+	}
+}
+
+// CryptocurrencyParseAndCheckMultisig is the multisig counterpart to
+// CryptocurrencyParseAndCheck: in addition to checking that address is
+// well-formed for its coin family, it verifies that address is consistent
+// with policy -- either by recomputing the M-of-N redeem script's P2SH/P2WSH
+// hash from policy.CoSigners and comparing it to the address's decoded
+// bytes, or, for contract-style wallets, by trusting the asserted
+// ContractAddress.
+func CryptocurrencyParseAndCheckMultisig(address string, policy MultisigPolicy) (CryptocurrencyType, error) {
+	typ, raw, err := CryptocurrencyParseAndCheck(address)
+	if err != nil {
+		return 0, err
+	}
+	if err := policy.Validate(); err != nil {
+		return 0, err
+	}
+	if policy.ContractAddress != "" {
+		if policy.ContractAddress != address {
+			return 0, fmt.Errorf("contract address %q does not match registered address %q", policy.ContractAddress, address)
+		}
+		return typ, nil
+	}
+	// The redeem-script hash path only has meaning for Bitcoin-style
+	// P2SH/P2WSH addresses -- a 20 or 32-byte decoded address from some
+	// other family isn't a script hash at all, so comparing byte lengths
+	// alone would happily "verify" a forged policy against it. Coins like
+	// Ethereum must go through ContractAddress instead.
+	if typ != CryptocurrencyTypeBTC && typ != CryptocurrencyTypeBTCSegwit {
+		return 0, fmt.Errorf("multisig co-signer policies are only supported for Bitcoin P2SH/P2WSH addresses; %s must use ContractAddress", typ)
+	}
+	expected, err := policy.DeriveMultisigScriptHash(len(raw))
+	if err != nil {
+		return 0, err
+	}
+	if !bytes.Equal(raw, expected) {
+		return 0, fmt.Errorf("address does not match the derived %d-of-%d multisig script hash", policy.Required, len(policy.CoSigners))
+	}
+	return typ, nil
+}
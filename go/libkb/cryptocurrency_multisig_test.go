@@ -0,0 +1,118 @@
+// Copyright 2018 Keybase, Inc. All rights reserved. Use of
+// this source code is governed by the included BSD license.
+
+package libkb
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func testPubKeys(n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		key := make([]byte, 33)
+		key[0] = 0x02
+		key[1] = byte(i + 1)
+		keys[i] = hex.EncodeToString(key)
+	}
+	return keys
+}
+
+func TestMultisigPolicyValidate(t *testing.T) {
+	cases := []struct {
+		name    string
+		policy  MultisigPolicy
+		wantErr bool
+	}{
+		{"valid 2-of-3", MultisigPolicy{Required: 2, CoSigners: testPubKeys(3)}, false},
+		{"threshold zero", MultisigPolicy{Required: 0, CoSigners: testPubKeys(3)}, true},
+		{"threshold exceeds cosigners", MultisigPolicy{Required: 4, CoSigners: testPubKeys(3)}, true},
+		{"no cosigners", MultisigPolicy{Required: 1, CoSigners: nil}, true},
+		{"too many cosigners", MultisigPolicy{Required: 1, CoSigners: testPubKeys(17)}, true},
+		{"valid contract address", MultisigPolicy{ContractAddress: "0xdeadbeef"}, false},
+		{"contract and cosigners both set", MultisigPolicy{ContractAddress: "0xdeadbeef", CoSigners: testPubKeys(1)}, true},
+	}
+	for _, c := range cases {
+		err := c.policy.Validate()
+		if c.wantErr && err == nil {
+			t.Errorf("%s: expected error, got nil", c.name)
+		}
+		if !c.wantErr && err != nil {
+			t.Errorf("%s: unexpected error: %s", c.name, err)
+		}
+	}
+}
+
+func TestMultisigRedeemScript(t *testing.T) {
+	policy := MultisigPolicy{Required: 2, CoSigners: testPubKeys(3)}
+	script, err := policy.RedeemScript()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if script[0] != opN(2) {
+		t.Errorf("expected leading OP_2, got %#x", script[0])
+	}
+	if script[len(script)-1] != opCheckMultisig {
+		t.Errorf("expected trailing OP_CHECKMULTISIG, got %#x", script[len(script)-1])
+	}
+	if script[len(script)-2] != opN(3) {
+		t.Errorf("expected OP_3 before OP_CHECKMULTISIG, got %#x", script[len(script)-2])
+	}
+
+	// Changing a co-signer should change the script (and therefore the
+	// hash), so swapped-out signers can't forge the same address.
+	other := MultisigPolicy{Required: 2, CoSigners: testPubKeys(3)}
+	other.CoSigners[0] = testPubKeys(4)[3]
+	otherScript, err := other.RedeemScript()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if hex.EncodeToString(script) == hex.EncodeToString(otherScript) {
+		t.Errorf("expected differing co-signers to produce differing scripts")
+	}
+}
+
+func TestMultisigRedeemScriptBadCoSigner(t *testing.T) {
+	policy := MultisigPolicy{Required: 1, CoSigners: []string{"not-hex"}}
+	if _, err := policy.RedeemScript(); err == nil {
+		t.Errorf("expected an error for a non-hex co-signer")
+	}
+
+	policy = MultisigPolicy{Required: 1, CoSigners: []string{"aabb"}}
+	if _, err := policy.RedeemScript(); err == nil {
+		t.Errorf("expected an error for a co-signer of the wrong length")
+	}
+}
+
+func TestDeriveMultisigScriptHash(t *testing.T) {
+	policy := MultisigPolicy{Required: 2, CoSigners: testPubKeys(3)}
+	script, err := policy.RedeemScript()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	p2sh, err := policy.DeriveMultisigScriptHash(20)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(p2sh) != 20 {
+		t.Errorf("expected a 20-byte P2SH hash, got %d bytes", len(p2sh))
+	}
+	if hex.EncodeToString(p2sh) != hex.EncodeToString(Hash160(script)) {
+		t.Errorf("P2SH hash did not match HASH160(script)")
+	}
+
+	p2wsh, err := policy.DeriveMultisigScriptHash(32)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(p2wsh) != 32 {
+		t.Errorf("expected a 32-byte P2WSH hash, got %d bytes", len(p2wsh))
+	}
+
+	if _, err := policy.DeriveMultisigScriptHash(25); err == nil {
+		t.Errorf("expected an error for a script hash length that is neither P2SH nor P2WSH")
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2021 Keybase, Inc. All rights reserved. Use of
+// this source code is governed by the included BSD license.
+
+package libkb
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// ExternalChainSigner signs a digest with the private key behind a
+// cryptocurrency address, without ever handing the key material to the
+// caller. CryptocurrencyEngine uses this instead of CryptocurrencyUI when
+// RegisterAddressArg.SignerURI is set, so the ProveControl flow works for
+// addresses whose keys live in a hardware wallet, an agent process, or a
+// KMS rather than on the machine running the engine.
+type ExternalChainSigner interface {
+	Sign(ctx context.Context, address string, digest []byte) (sig []byte, kind SignatureKind, err error)
+}
+
+// ResolveExternalChainSigner picks an ExternalChainSigner implementation
+// based on uri's scheme. Supported schemes are "file" (a local keyfile,
+// mostly useful for testing), "unix" (an ssh-agent-style socket), and the
+// enterprise KMS schemes "awskms", "pkcs11", and "yubihsm".
+func ResolveExternalChainSigner(uri string) (ExternalChainSigner, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signer URI: %s", err)
+	}
+	switch u.Scheme {
+	case "file":
+		return &fileChainSigner{path: u.Path}, nil
+	case "unix":
+		return &agentChainSigner{socketPath: u.Path}, nil
+	case "awskms", "pkcs11", "yubihsm":
+		return nil, fmt.Errorf("signer scheme %q requires vendoring its SDK, which this build doesn't include", u.Scheme)
+	default:
+		return nil, fmt.Errorf("unsupported signer URI scheme %q", u.Scheme)
+	}
+}
+
+// fileChainSigner reads a raw ed25519 private key from a local file. It
+// exists mainly so ProveControl can be exercised in tests and by advanced
+// users without a real external signer; most real deployments will use
+// agentChainSigner or a KMS scheme instead.
+type fileChainSigner struct {
+	path string
+}
+
+func (s *fileChainSigner) Sign(ctx context.Context, address string, digest []byte) ([]byte, SignatureKind, error) {
+	raw, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(raw) != ed25519.PrivateKeySize {
+		return nil, "", fmt.Errorf("keyfile %q is not a raw ed25519 private key", s.path)
+	}
+	sig := ed25519.Sign(ed25519.PrivateKey(raw), digest)
+	return sig, SignatureKindEd25519, nil
+}
+
+// agentChainSigner forwards signing requests to a long-running process
+// over a Unix domain socket, analogous to ssh-agent: the caller never sees
+// the key, only a signature over the digest it sent.
+type agentChainSigner struct {
+	socketPath string
+}
+
+type agentSignRequest struct {
+	Address string `json:"address"`
+	Digest  string `json:"digest"`
+}
+
+type agentSignResponse struct {
+	Signature string `json:"signature"`
+	Kind      string `json:"kind"`
+	Error     string `json:"error,omitempty"`
+}
+
+func (s *agentChainSigner) Sign(ctx context.Context, address string, digest []byte) ([]byte, SignatureKind, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "unix", s.socketPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not reach chain signer agent at %q: %s", s.socketPath, err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return nil, "", err
+		}
+	}
+
+	// DialContext only covers connection setup; a hung agent that accepts
+	// the connection but never writes back could otherwise block the
+	// encode/decode below forever. Watch ctx alongside them and yank the
+	// connection out from under a stuck read/write so cancellation works
+	// even when the caller didn't set an explicit deadline.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	req := agentSignRequest{Address: address, Digest: hex.EncodeToString(digest)}
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		if ctx.Err() != nil {
+			return nil, "", ctx.Err()
+		}
+		return nil, "", err
+	}
+
+	var res agentSignResponse
+	if err := json.NewDecoder(conn).Decode(&res); err != nil {
+		if ctx.Err() != nil {
+			return nil, "", ctx.Err()
+		}
+		return nil, "", err
+	}
+	if res.Error != "" {
+		return nil, "", fmt.Errorf("chain signer agent: %s", res.Error)
+	}
+	sig, err := hex.DecodeString(res.Signature)
+	if err != nil {
+		return nil, "", err
+	}
+	return sig, SignatureKind(strings.ToLower(res.Kind)), nil
+}
@@ -0,0 +1,158 @@
+// Copyright 2021 Keybase, Inc. All rights reserved. Use of
+// this source code is governed by the included BSD license.
+
+package libkb
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestResolveExternalChainSignerSchemes(t *testing.T) {
+	if _, err := ResolveExternalChainSigner("file:///tmp/key"); err != nil {
+		t.Errorf("unexpected error resolving file:// scheme: %s", err)
+	}
+	if _, err := ResolveExternalChainSigner("unix:///tmp/agent.sock"); err != nil {
+		t.Errorf("unexpected error resolving unix:// scheme: %s", err)
+	}
+	for _, scheme := range []string{"awskms", "pkcs11", "yubihsm"} {
+		if _, err := ResolveExternalChainSigner(scheme + "://key-id"); err == nil {
+			t.Errorf("expected scheme %q to be rejected as unsupported in this build", scheme)
+		}
+	}
+	if _, err := ResolveExternalChainSigner("made-up://whatever"); err == nil {
+		t.Errorf("expected an unrecognized scheme to error")
+	}
+}
+
+func TestFileChainSignerSign(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "key")
+	if err := ioutil.WriteFile(path, priv, 0600); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	signer := &fileChainSigner{path: path}
+	digest := []byte("some 32-byte-ish digest value!!")
+	sig, kind, err := signer.Sign(context.Background(), "some-address", digest)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if kind != SignatureKindEd25519 {
+		t.Errorf("expected kind %q, got %q", SignatureKindEd25519, kind)
+	}
+	if !ed25519.Verify(pub, digest, sig) {
+		t.Errorf("expected the signature to verify against the key's public half")
+	}
+}
+
+func TestFileChainSignerSignBadKeySize(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "key")
+	if err := ioutil.WriteFile(path, []byte("too short"), 0600); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	signer := &fileChainSigner{path: path}
+	if _, _, err := signer.Sign(context.Background(), "some-address", []byte("digest")); err == nil {
+		t.Errorf("expected an error signing with a malformed keyfile")
+	}
+}
+
+// startTestAgent starts a fake chain-signer agent on a unix socket that
+// replies to every request with a fixed signature after an optional delay,
+// closing the listener when the test ends.
+func startTestAgent(t *testing.T, delay time.Duration) string {
+	t.Helper()
+	dir := t.TempDir()
+	socketPath := filepath.Join(dir, "agent.sock")
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				var req agentSignRequest
+				if err := json.NewDecoder(conn).Decode(&req); err != nil {
+					return
+				}
+				if delay > 0 {
+					time.Sleep(delay)
+				}
+				res := agentSignResponse{
+					Signature: hex.EncodeToString([]byte("deadbeef")),
+					Kind:      "SECP256K1",
+				}
+				_ = json.NewEncoder(conn).Encode(res)
+			}()
+		}
+	}()
+
+	return socketPath
+}
+
+func TestAgentChainSignerSign(t *testing.T) {
+	socketPath := startTestAgent(t, 0)
+	signer := &agentChainSigner{socketPath: socketPath}
+
+	sig, kind, err := signer.Sign(context.Background(), "some-address", []byte("digest"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if kind != SignatureKindSecp256k1 {
+		t.Errorf("expected kind %q lowercased from the agent's response, got %q", SignatureKindSecp256k1, kind)
+	}
+	if hex.EncodeToString(sig) != hex.EncodeToString([]byte("deadbeef")) {
+		t.Errorf("unexpected signature bytes: %x", sig)
+	}
+}
+
+func TestAgentChainSignerSignNoAgent(t *testing.T) {
+	signer := &agentChainSigner{socketPath: filepath.Join(os.TempDir(), "no-such-agent.sock")}
+	if _, _, err := signer.Sign(context.Background(), "some-address", []byte("digest")); err == nil {
+		t.Errorf("expected an error dialing a nonexistent agent socket")
+	}
+}
+
+func TestAgentChainSignerSignRespectsContextCancellation(t *testing.T) {
+	socketPath := startTestAgent(t, 200*time.Millisecond)
+	signer := &agentChainSigner{socketPath: socketPath}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, _, err := signer.Sign(ctx, "some-address", []byte("digest"))
+	elapsed := time.Since(start)
+
+	if err == nil {
+		t.Fatalf("expected a hung agent to surface an error once the context expires")
+	}
+	if elapsed >= 200*time.Millisecond {
+		t.Errorf("expected Sign to return once the 20ms context deadline passed, took %s", elapsed)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2015 Keybase, Inc. All rights reserved. Use of
+// this source code is governed by the included BSD license.
+
+package libkb
+
+// UIKind identifies one of the UIs an engine can declare in RequiredUIs,
+// so the caller knows which UI implementations it needs to wire up before
+// running the engine.
+type UIKind string
+
+const (
+	LogUIKind    UIKind = "logUi"
+	SecretUIKind UIKind = "secretUi"
+)
+
+// UIConsumer is something (typically a sub-engine) that itself requires
+// UIs, so an engine can report its transitive UI requirements via
+// SubConsumers.
+type UIConsumer interface {
+	Name() string
+	RequiredUIs() []UIKind
+	SubConsumers() []UIConsumer
+}
+
+// LogUI and SecretUI are the two UIs every cryptocurrency-registration
+// engine declares; only the methods this package actually calls through
+// them are declared here.
+type LogUI interface {
+	Info(format string, args ...interface{})
+}
+
+type SecretUI interface {
+	GetPassphrase(pinentry string) (string, error)
+}
+
+// SecretKeyType picks which of the user's keys GetSecretKeyWithPrompt
+// should unlock.
+type SecretKeyType int
+
+const (
+	DeviceSigningKeyType SecretKeyType = iota
+	DeviceEncryptionKeyType
+)
+
+// SecretKeyArg selects which secret key to prompt for.
+type SecretKeyArg struct {
+	Me      *User
+	KeyType SecretKeyType
+}
+
+// SecretKeyPromptArg bundles a SecretKeyArg with the UI and reason used to
+// prompt the user if the key needs to be unlocked.
+type SecretKeyPromptArg struct {
+	Ska      SecretKeyArg
+	Reason   string
+	SecretUI SecretUI
+}
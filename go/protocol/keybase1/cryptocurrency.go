@@ -0,0 +1,41 @@
+// Auto-generated by avdl-compiler v1.4.2 (dependent on protocol/cryptocurrency.avdl)
+
+package keybase1
+
+// MultisigPolicy lets RegisterAddressArg register an M-of-N multisig
+// address instead of one derived from a single signing key. CoSigners are
+// the hex-encoded co-signer public keys making up the redeem script;
+// ContractAddress is set instead for contract-style (e.g. Gnosis Safe)
+// wallets.
+type MultisigPolicy struct {
+	Required        int      `codec:"required" json:"required"`
+	CoSigners       []string `codec:"coSigners" json:"coSigners"`
+	ContractAddress string   `codec:"contractAddress" json:"contractAddress"`
+}
+
+type RegisterAddressArg struct {
+	SessionID      int             `codec:"sessionID" json:"sessionID"`
+	Address        string          `codec:"address" json:"address"`
+	Force          bool            `codec:"force" json:"force"`
+	WantedFamily   string          `codec:"wantedFamily" json:"wantedFamily"`
+	SigVersion     int             `codec:"sigVersion" json:"sigVersion"`
+	MultisigPolicy *MultisigPolicy `codec:"multisigPolicy,omitempty" json:"multisigPolicy,omitempty"`
+	ProveControl   bool            `codec:"proveControl" json:"proveControl"`
+	SignerURI      string          `codec:"signerURI" json:"signerURI"`
+}
+
+type RegisterAddressRes struct {
+	Family string `codec:"family" json:"family"`
+	Type   string `codec:"type" json:"type"`
+}
+
+type RegisterAddressesArg struct {
+	SessionID  int      `codec:"sessionID" json:"sessionID"`
+	Addresses  []string `codec:"addresses" json:"addresses"`
+	Force      bool     `codec:"force" json:"force"`
+	SigVersion int      `codec:"sigVersion" json:"sigVersion"`
+}
+
+type RegisterAddressesRes struct {
+	Addresses []string `codec:"addresses" json:"addresses"`
+}